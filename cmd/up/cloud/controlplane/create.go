@@ -16,24 +16,53 @@ package controlplane
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
 
 	cp "github.com/upbound/up-sdk-go/service/controlplanes"
 	"github.com/upbound/up/internal/cloud"
 )
 
+const (
+	errMarshalControlPlane = "unable to marshal control plane"
+)
+
 // CreateCmd creates a hosted control plane on Upbound Cloud.
 type CreateCmd struct {
 	Name string `arg:"" required:"" help:"Name of control plane."`
 
 	Description string `short:"d" help:"Description for control plane."`
+	Output      string `short:"o" help:"Output format for result." enum:"table,json,yaml" default:"table"`
 }
 
 // Run executes the create command.
 func (c *CreateCmd) Run(client *cp.Client, cloudCtx *cloud.Context) error {
-	_, err := client.Create(context.Background(), &cp.ControlPlaneCreateParameters{
+	ctrl, err := client.Create(context.Background(), &cp.ControlPlaneCreateParameters{
 		Account:     cloudCtx.Account,
 		Name:        c.Name,
 		Description: c.Description,
 	})
-	return err
+	if err != nil {
+		return err
+	}
+	switch c.Output {
+	case "json":
+		b, err := json.MarshalIndent(ctrl, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, errMarshalControlPlane)
+		}
+		fmt.Println(string(b))
+	case "yaml":
+		b, err := yaml.Marshal(ctrl)
+		if err != nil {
+			return errors.Wrap(err, errMarshalControlPlane)
+		}
+		fmt.Println(string(b))
+	default:
+		fmt.Printf("%s created\n", c.Name)
+	}
+	return nil
 }
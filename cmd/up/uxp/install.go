@@ -16,6 +16,7 @@ package uxp
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"os"
 
@@ -33,13 +34,23 @@ import (
 const (
 	errReadParametersFile     = "unable to read parameters file"
 	errParseInstallParameters = "unable to parse install parameters"
+	errSkipAndOnlyCRDs        = "--skip-crds and --only-crds are mutually exclusive"
 )
 
 // AfterApply sets default values in command after assignment and validation.
 func (c *installCmd) AfterApply(uxpCtx *uxp.Context) error {
+	if c.SkipCRDs && c.OnlyCRDs {
+		return errors.New(errSkipAndOnlyCRDs)
+	}
 	installer, err := helm.NewInstaller(uxpCtx.Kubeconfig,
 		helm.WithNamespace(uxpCtx.Namespace),
-		helm.AllowUnstableVersions(c.Unstable))
+		helm.AllowUnstableVersions(c.Unstable),
+		helm.WithLabels(c.Label),
+		helm.WithAnnotations(c.Annotation),
+		helm.SkipCRDs(c.SkipCRDs),
+		helm.OnlyCRDs(c.OnlyCRDs),
+		helm.WithRepoCAFile(c.RepoCAFile),
+		helm.WithRepoCredentials(c.RepoUsername, c.RepoPassword))
 	if err != nil {
 		return err
 	}
@@ -75,9 +86,17 @@ type installCmd struct {
 
 	Version string `arg:"" optional:"" help:"UXP version to install."`
 
-	Unstable bool              `help:"Allow installing unstable UXP versions."`
-	Set      map[string]string `help:"Set install parameters."`
-	File     *os.File          `short:"f" help:"Parameters file for install."`
+	Unstable     bool              `help:"Allow installing unstable UXP versions."`
+	Set          map[string]string `help:"Set install parameters."`
+	File         *os.File          `short:"f" help:"Parameters file for install."`
+	Label        map[string]string `help:"Label to apply to all installed resources."`
+	Annotation   map[string]string `help:"Annotation to apply to all installed resources."`
+	SkipCRDs     bool              `help:"Skip applying CRDs."`
+	OnlyCRDs     bool              `help:"Only apply CRDs."`
+	Output       string            `short:"o" help:"Output format for result." enum:"table,json,yaml" default:"table"`
+	RepoCAFile   string            `help:"CA bundle to use to verify the chart repo's certificate."`
+	RepoUsername string            `help:"Username to authenticate to the chart repo."`
+	RepoPassword string            `help:"Password to authenticate to the chart repo."`
 }
 
 // Run executes the install command.
@@ -95,9 +114,22 @@ func (c *installCmd) Run(uxpCtx *uxp.Context) error {
 	if err != nil {
 		return errors.Wrap(err, errParseInstallParameters)
 	}
-	err = c.installer.Install(c.Version, params)
+	if err := c.installer.Install(c.Version, params); err != nil {
+		return err
+	}
+	// --only-crds never creates a release, so there is no release info to
+	// fetch regardless of the requested output format.
+	if c.OnlyCRDs {
+		fmt.Printf("UXP CRDs installed\n")
+		return nil
+	}
+	if c.Output == "table" {
+		fmt.Printf("UXP installed\n")
+		return nil
+	}
+	info, err := c.installer.GetReleaseInfo()
 	if err != nil {
 		return err
 	}
-	return nil
+	return printReleaseInfo(c.Output, info)
 }
@@ -0,0 +1,79 @@
+// Copyright 2021 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uxp
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+
+	"github.com/upbound/up/internal/uxp"
+	"github.com/upbound/up/internal/uxp/installers/helm"
+)
+
+const (
+	errMarshalReleaseInfo = "unable to marshal release info"
+)
+
+// AfterApply sets default values in command after assignment and validation.
+func (c *statusCmd) AfterApply(uxpCtx *uxp.Context) error {
+	installer, err := helm.NewInstaller(uxpCtx.Kubeconfig,
+		helm.WithNamespace(uxpCtx.Namespace))
+	if err != nil {
+		return err
+	}
+	c.installer = installer
+	return nil
+}
+
+// statusCmd reports the status of the installed UXP release.
+type statusCmd struct {
+	installer uxp.Installer
+
+	Output string `short:"o" help:"Output format for result." enum:"table,json,yaml" default:"table"`
+}
+
+// Run executes the status command.
+func (c *statusCmd) Run() error {
+	info, err := c.installer.GetReleaseInfo()
+	if err != nil {
+		return err
+	}
+	return printReleaseInfo(c.Output, info)
+}
+
+// printReleaseInfo writes info to stdout in the requested output format.
+func printReleaseInfo(output string, info *uxp.ReleaseInfo) error {
+	switch output {
+	case "json":
+		b, err := json.MarshalIndent(info, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, errMarshalReleaseInfo)
+		}
+		fmt.Println(string(b))
+	case "yaml":
+		b, err := yaml.Marshal(info)
+		if err != nil {
+			return errors.Wrap(err, errMarshalReleaseInfo)
+		}
+		fmt.Println(string(b))
+	default:
+		fmt.Printf("NAME\tCHART\tVERSION\tNAMESPACE\tREVISION\n")
+		fmt.Printf("%s\t%s\t%s\t%s\t%d\n", info.Name, info.Chart, info.Version, info.Namespace, info.Revision)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+// Copyright 2021 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uxp
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+
+	"github.com/upbound/up/internal/uxp"
+	"github.com/upbound/up/internal/uxp/installers/helm"
+)
+
+const (
+	errReadUpgradeParametersFile  = "unable to read parameters file"
+	errParseUpgradeParameters     = "unable to parse upgrade parameters"
+	errUpgradeConfirmationMissing = "re-run with --confirm to upgrade across a minor version boundary"
+	errUpgradeSkipAndOnlyCRDs     = "--skip-crds and --only-crds are mutually exclusive"
+)
+
+// AfterApply sets default values in command after assignment and validation.
+func (c *upgradeCmd) AfterApply(uxpCtx *uxp.Context) error {
+	if c.SkipCRDs && c.OnlyCRDs {
+		return errors.New(errUpgradeSkipAndOnlyCRDs)
+	}
+	installer, err := helm.NewInstaller(uxpCtx.Kubeconfig,
+		helm.WithNamespace(uxpCtx.Namespace),
+		helm.AllowUnstableVersions(c.Unstable),
+		helm.Force(c.Force),
+		helm.Confirm(c.Confirm),
+		helm.WithLabels(c.Label),
+		helm.WithAnnotations(c.Annotation),
+		helm.SkipCRDs(c.SkipCRDs),
+		helm.OnlyCRDs(c.OnlyCRDs),
+		helm.WithRepoCAFile(c.RepoCAFile),
+		helm.WithRepoCredentials(c.RepoUsername, c.RepoPassword))
+	if err != nil {
+		return err
+	}
+	c.installer = installer
+	base := map[string]interface{}{}
+	if c.File != nil {
+		defer c.File.Close() //nolint:errcheck,gosec
+		b, err := io.ReadAll(c.File)
+		if err != nil {
+			return errors.Wrap(err, errReadUpgradeParametersFile)
+		}
+		if err := yaml.Unmarshal(b, &base); err != nil {
+			return errors.Wrap(err, errReadUpgradeParametersFile)
+		}
+		if err := c.File.Close(); err != nil {
+			return errors.Wrap(err, errReadUpgradeParametersFile)
+		}
+	}
+	c.parser = helm.NewParser(base, c.Set)
+	return nil
+}
+
+// upgradeCmd upgrades UXP.
+type upgradeCmd struct {
+	installer uxp.Installer
+	parser    uxp.ParameterParser
+
+	Version string `arg:"" optional:"" help:"UXP version to upgrade to."`
+
+	Unstable     bool              `help:"Allow upgrading to unstable UXP versions."`
+	Set          map[string]string `help:"Set upgrade parameters."`
+	File         *os.File          `short:"f" help:"Parameters file for upgrade."`
+	Force        bool              `help:"Force upgrade even if the target version skips more than one minor version."`
+	Confirm      bool              `help:"Confirm an upgrade that crosses a minor version boundary."`
+	Label        map[string]string `help:"Label to apply to all installed resources."`
+	Annotation   map[string]string `help:"Annotation to apply to all installed resources."`
+	SkipCRDs     bool              `help:"Skip applying CRDs."`
+	OnlyCRDs     bool              `help:"Only apply CRDs."`
+	Output       string            `short:"o" help:"Output format for result." enum:"table,json,yaml" default:"table"`
+	RepoCAFile   string            `help:"CA bundle to use to verify the chart repo's certificate."`
+	RepoUsername string            `help:"Username to authenticate to the chart repo."`
+	RepoPassword string            `help:"Password to authenticate to the chart repo."`
+}
+
+// Run executes the upgrade command.
+func (c *upgradeCmd) Run() error {
+	params, err := c.parser.Parse()
+	if err != nil {
+		return errors.Wrap(err, errParseUpgradeParameters)
+	}
+	if err := c.installer.Upgrade(c.Version, params); err != nil {
+		if errors.Is(err, uxp.ErrConfirmationMissing) {
+			return errors.Wrap(err, errUpgradeConfirmationMissing)
+		}
+		return err
+	}
+	// --only-crds never invokes the upgrade client, so there is no new
+	// release info to fetch regardless of the requested output format.
+	if c.OnlyCRDs {
+		fmt.Printf("UXP CRDs installed\n")
+		return nil
+	}
+	if c.Output == "table" {
+		fmt.Printf("UXP upgraded\n")
+		return nil
+	}
+	info, err := c.installer.GetReleaseInfo()
+	if err != nil {
+		return err
+	}
+	return printReleaseInfo(c.Output, info)
+}
@@ -0,0 +1,132 @@
+// Copyright 2021 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+
+	"github.com/pkg/errors"
+	"helm.sh/helm/v3/pkg/chart"
+	clientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	crdFieldManager = "up"
+	crdKind         = "CustomResourceDefinition"
+
+	errApplyCRD  = "could not apply CRD"
+	errDecodeCRD = "could not decode CRD"
+)
+
+// crdClient applies CRDs to a cluster.
+type crdClient interface {
+	ApplyCRD(ctx context.Context, rawCRD []byte) error
+}
+
+// apiExtensionsCRDClient is a crdClient that uses the apiextensions-apiserver
+// client-go clientset to server-side apply CRDs with a dedicated field
+// manager, so that re-applying the same CRD converges rather than
+// conflicting.
+type apiExtensionsCRDClient struct {
+	client clientset.Interface
+}
+
+// newCRDClient builds the default crdClient for the given REST config.
+func newCRDClient(config *rest.Config) (crdClient, error) {
+	c, err := clientset.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return &apiExtensionsCRDClient{client: c}, nil
+}
+
+// ApplyCRD server-side applies the supplied raw CRD manifest. Only the name
+// is decoded out of rawCRD; the bytes sent to the API server are rawCRD
+// itself (converted to JSON, which is a direct syntactic transcoding and not
+// a round trip through a typed struct), so that fields the vendored
+// apiextensions-apiserver types don't model are not silently dropped.
+func (c *apiExtensionsCRDClient) ApplyCRD(ctx context.Context, rawCRD []byte) error {
+	meta := &metav1.PartialObjectMetadata{}
+	if err := yaml.Unmarshal(rawCRD, meta); err != nil {
+		return errors.Wrap(err, errDecodeCRD)
+	}
+	data, err := yaml.YAMLToJSON(rawCRD)
+	if err != nil {
+		return errors.Wrap(err, errDecodeCRD)
+	}
+	force := true
+	_, err = c.client.ApiextensionsV1().CustomResourceDefinitions().Patch(
+		ctx,
+		meta.GetName(),
+		types.ApplyPatchType,
+		data,
+		metav1.PatchOptions{FieldManager: crdFieldManager, Force: &force},
+	)
+	return errors.Wrap(err, errApplyCRD)
+}
+
+// applyCRDs applies every CRD packaged with c's crds/ directory, as well as
+// any CustomResourceDefinition document found in renderedManifest, which may
+// contain CRDs rendered from the chart's templates. Each crds/ file and
+// renderedManifest are split on YAML document boundaries rather than the
+// literal string "\n---\n" so that a file holding several CRDs has each of
+// them applied, and a CRD whose schema contains that sequence, e.g. in a
+// description field, is not split mid-document.
+func applyCRDs(ctx context.Context, client crdClient, c *chart.Chart, renderedManifest string) error {
+	for _, crdObj := range c.CRDObjects() {
+		if err := applyCRDDocuments(ctx, client, crdObj.File.Data); err != nil {
+			return err
+		}
+	}
+	return applyCRDDocuments(ctx, client, []byte(renderedManifest))
+}
+
+// applyCRDDocuments applies each CustomResourceDefinition document in data,
+// skipping documents of any other kind.
+func applyCRDDocuments(ctx context.Context, client crdClient, data []byte) error {
+	reader := k8syaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(data)))
+	for {
+		raw, err := reader.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return errors.Wrap(err, errDecodeCRD)
+		}
+		raw = bytes.TrimSpace(raw)
+		if len(raw) == 0 {
+			continue
+		}
+		doc := &metav1.TypeMeta{}
+		if err := yaml.Unmarshal(raw, doc); err != nil {
+			return errors.Wrap(err, errDecodeCRD)
+		}
+		if doc.Kind != crdKind {
+			continue
+		}
+		if err := client.ApplyCRD(ctx, raw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -15,13 +15,16 @@
 package helm
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 
-	"github.com/Masterminds/semver/v3"
 	"github.com/crossplane/crossplane-runtime/pkg/logging"
 	"github.com/pkg/errors"
 	"github.com/spf13/afero"
@@ -29,11 +32,16 @@ import (
 	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/chart/loader"
 	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/downloader"
+	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/postrender"
 	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/repo"
 	"helm.sh/helm/v3/pkg/storage/driver"
 	"k8s.io/client-go/rest"
 
 	"github.com/upbound/up/internal/uxp"
+	"github.com/upbound/up/internal/version"
 )
 
 const (
@@ -57,9 +65,14 @@ const (
 	errCorruptTempDirFmt        = "corrupt chart tmp directory, consider removing cache (%s)"
 	errMoveLatest               = "could not move latest pulled chart to cache"
 
-	errUpgradeCrossplaneVersion    = "cannot upgrade crossplane to universal-crossplane with version mismatch"
+	errVerifyTargetVersion         = "could not identify target version"
 	errFailedUpgradeFailedRollback = "failed upgrade resulted in a failed rollback"
 	errFailedUpgradeRollback       = "failed upgrade was rolled back"
+
+	errApplyChartCRDs       = "could not apply chart CRDs"
+	errRenderChartTemplates = "could not render chart templates"
+
+	errResolveChartURL = "could not resolve chart URL in repo"
 )
 
 type helmPuller interface {
@@ -68,16 +81,70 @@ type helmPuller interface {
 	SetVersion(string)
 }
 
+// puller downloads charts. It drives downloader.ChartDownloader directly
+// rather than action.Pull because action.Pull builds its getters internally
+// from cli.EnvSettings and has no hook for overriding them, but we need to
+// set a custom User-Agent and transport on the getter used to fetch charts.
 type puller struct {
-	*action.Pull
+	destDir   string
+	version   string
+	repoURL   string
+	caFile    string
+	username  string
+	password  string
+	userAgent string
+	transport *http.Transport
+	settings  *cli.EnvSettings
 }
 
 func (p *puller) SetDestDir(dir string) {
-	p.DestDir = dir
+	p.destDir = dir
 }
 
 func (p *puller) SetVersion(version string) {
-	p.Version = version
+	p.version = version
+}
+
+// Run resolves chartRef against the configured repo and downloads it to
+// destDir, returning the path it was saved to.
+func (p *puller) Run(chartRef string) (string, error) {
+	opts := []getter.Option{
+		getter.WithBasicAuth(p.username, p.password),
+		getter.WithTLSClientConfig("", "", p.caFile),
+		getter.WithUserAgent(p.userAgent),
+	}
+	if p.transport != nil {
+		opts = append(opts, getter.WithTransport(p.transport))
+	}
+	c := downloader.ChartDownloader{
+		Out:              io.Discard,
+		Verify:           downloader.VerifyNever,
+		Getters:          getter.All(p.settings),
+		Options:          opts,
+		RepositoryConfig: p.settings.RepositoryConfig,
+		RepositoryCache:  p.settings.RepositoryCache,
+	}
+	// The repo index is fetched with getters built from the providers alone,
+	// so the User-Agent and transport are added through the HTTP provider.
+	httpOpts := []getter.Option{getter.WithUserAgent(p.userAgent)}
+	if p.transport != nil {
+		httpOpts = append(httpOpts, getter.WithTransport(p.transport))
+	}
+	indexGetters := append(getter.Providers{{
+		Schemes: []string{"http", "https"},
+		New: func(o ...getter.Option) (getter.Getter, error) {
+			return getter.NewHTTPGetter(append(o, httpOpts...)...)
+		},
+	}}, getter.All(p.settings)...)
+	chartURL, err := repo.FindChartInAuthAndTLSAndPassRepoURL(p.repoURL, p.username, p.password, chartRef, p.version, "", "", p.caFile, false, false, indexGetters)
+	if err != nil {
+		return "", errors.Wrap(err, errResolveChartURL)
+	}
+	saved, _, err := c.DownloadTo(chartURL, p.version, p.destDir)
+	if err != nil {
+		return "", err
+	}
+	return saved, nil
 }
 
 type helmGetter interface {
@@ -118,6 +185,16 @@ type installer struct {
 	unstable        bool
 	rollbackOnError bool
 	force           bool
+	confirm         bool
+	labels          map[string]string
+	annotations     map[string]string
+	skipCRDs        bool
+	onlyCRDs        bool
+	userAgent       string
+	transport       *http.Transport
+	repoCAFile      string
+	repoUsername    string
+	repoPassword    string
 	home            HomeDirFn
 	fs              afero.Fs
 	tempDir         TempDirFn
@@ -130,6 +207,8 @@ type installer struct {
 	upgradeClient   helmUpgrader
 	rollbackClient  helmRollbacker
 	uninstallClient helmUninstaller
+	renderClient    helmInstaller
+	crdClient       crdClient
 
 	// Loader
 	load LoaderFn
@@ -195,6 +274,80 @@ func Force(f bool) InstallerModifierFn {
 	}
 }
 
+// Confirm indicates that the caller has explicitly confirmed an upgrade
+// that crosses a minor version boundary.
+func Confirm(c bool) InstallerModifierFn {
+	return func(h *installer) {
+		h.confirm = c
+	}
+}
+
+// WithLabels sets labels that will be applied to all resources installed by
+// the helm installer via a post-renderer.
+func WithLabels(labels map[string]string) InstallerModifierFn {
+	return func(h *installer) {
+		h.labels = labels
+	}
+}
+
+// WithAnnotations sets annotations that will be applied to all resources
+// installed by the helm installer via a post-renderer.
+func WithAnnotations(annotations map[string]string) InstallerModifierFn {
+	return func(h *installer) {
+		h.annotations = annotations
+	}
+}
+
+// SkipCRDs skips applying CRDs prior to install or upgrade.
+func SkipCRDs(s bool) InstallerModifierFn {
+	return func(h *installer) {
+		h.skipCRDs = s
+	}
+}
+
+// OnlyCRDs limits install or upgrade to applying CRDs only.
+func OnlyCRDs(o bool) InstallerModifierFn {
+	return func(h *installer) {
+		h.onlyCRDs = o
+	}
+}
+
+// WithUserAgent sets the User-Agent sent when pulling charts. Defaults to
+// up/<version> (<os>/<arch>).
+func WithUserAgent(ua string) InstallerModifierFn {
+	return func(h *installer) {
+		h.userAgent = ua
+	}
+}
+
+// WithHTTPTransport sets the HTTP transport used when pulling charts,
+// allowing operators to route requests through a corporate proxy, present
+// mTLS credentials to a private mirror, or add request-level tracing. A
+// concrete *http.Transport is required because that is what Helm's HTTP
+// getter accepts.
+func WithHTTPTransport(t *http.Transport) InstallerModifierFn {
+	return func(h *installer) {
+		h.transport = t
+	}
+}
+
+// WithRepoCAFile sets a CA bundle used to verify the chart repo's
+// certificate.
+func WithRepoCAFile(f string) InstallerModifierFn {
+	return func(h *installer) {
+		h.repoCAFile = f
+	}
+}
+
+// WithRepoCredentials sets basic auth credentials used to authenticate to
+// the chart repo.
+func WithRepoCredentials(username, password string) InstallerModifierFn {
+	return func(h *installer) {
+		h.repoUsername = username
+		h.repoPassword = password
+	}
+}
+
 // NewInstaller builds a helm installer for UXP.
 func NewInstaller(config *rest.Config, modifiers ...InstallerModifierFn) (uxp.Installer, error) { // nolint:gocyclo
 	u, err := url.Parse(defaultRepoURL)
@@ -250,27 +403,50 @@ func NewInstaller(config *rest.Config, modifiers ...InstallerModifierFn) (uxp.In
 	}
 
 	// Pull Client
-	p := action.NewPull()
-	p.DestDir = h.cacheDir
-	p.Devel = h.unstable
-	p.Settings = &cli.EnvSettings{}
-	p.RepoURL = h.repoURL.String()
-	h.pullClient = &puller{p}
+	if h.userAgent == "" {
+		h.userAgent = fmt.Sprintf("up/%s (%s/%s)", version.Version, runtime.GOOS, runtime.GOARCH)
+	}
+	h.pullClient = &puller{
+		destDir:   h.cacheDir,
+		repoURL:   h.repoURL.String(),
+		caFile:    h.repoCAFile,
+		username:  h.repoUsername,
+		password:  h.repoPassword,
+		userAgent: h.userAgent,
+		transport: h.transport,
+		settings:  &cli.EnvSettings{},
+	}
 
 	// Get Client
 	h.getClient = action.NewGet(actionConfig)
 
+	var pr postrender.PostRenderer
+	if len(h.labels) > 0 || len(h.annotations) > 0 {
+		pr = &labelAnnotationPostRenderer{labels: h.labels, annotations: h.annotations}
+	}
+
 	// Install Client
 	ic := action.NewInstall(actionConfig)
 	ic.Namespace = h.namespace
 	ic.ReleaseName = h.chartName
+	ic.PostRenderer = pr
 	h.installClient = ic
 
 	// Upgrade Client
 	uc := action.NewUpgrade(actionConfig)
 	uc.Namespace = h.namespace
+	uc.PostRenderer = pr
 	h.upgradeClient = uc
 
+	// Render Client, used to discover CRDs rendered from the chart's
+	// templates without touching the cluster.
+	rc := action.NewInstall(actionConfig)
+	rc.Namespace = h.namespace
+	rc.ReleaseName = h.chartName
+	rc.ClientOnly = true
+	rc.DryRun = true
+	h.renderClient = rc
+
 	// Uninstall Client
 	h.uninstallClient = action.NewUninstall(actionConfig)
 
@@ -278,28 +454,51 @@ func NewInstaller(config *rest.Config, modifiers ...InstallerModifierFn) (uxp.In
 	rb := action.NewRollback(actionConfig)
 	h.rollbackClient = rb
 
+	// CRD Client
+	crds, err := newCRDClient(config)
+	if err != nil {
+		return nil, err
+	}
+	h.crdClient = crds
+
 	return h, nil
 }
 
 // GetCurrentVersion gets the current UXP version in the cluster.
 func (h *installer) GetCurrentVersion() (string, error) {
+	info, err := h.GetReleaseInfo()
+	if err != nil {
+		return "", err
+	}
+	return info.Version, nil
+}
+
+// GetReleaseInfo returns information about the currently installed UXP
+// release.
+func (h *installer) GetReleaseInfo() (*uxp.ReleaseInfo, error) {
 	var release *release.Release
 	var err error
 	release, err = h.getClient.Run(defaultChartName)
 	if err != nil && !errors.Is(err, driver.ErrReleaseNotFound) {
-		return "", err
+		return nil, err
 	}
 	if errors.Is(err, driver.ErrReleaseNotFound) {
 		// TODO(hasheddan): add logging indicating fallback to crossplane.
 		if release, err = h.getClient.Run(crossplaneChartName); err != nil {
-			return "", errors.Wrapf(err, errGetInstalledReleaseFmt, h.namespace)
+			return nil, errors.Wrapf(err, errGetInstalledReleaseFmt, h.namespace)
 		}
 		h.releaseName = crossplaneChartName
 	}
 	if release == nil || release.Chart == nil || release.Chart.Metadata == nil {
-		return "", errors.New(errVerifyInstalledVersion)
+		return nil, errors.New(errVerifyInstalledVersion)
 	}
-	return release.Chart.Metadata.Version, nil
+	return &uxp.ReleaseInfo{
+		Name:      release.Name,
+		Chart:     release.Chart.Metadata.Name,
+		Version:   release.Chart.Metadata.Version,
+		Namespace: release.Namespace,
+		Revision:  release.Version,
+	}, nil
 }
 
 // Install installs UXP in the cluster.
@@ -317,6 +516,12 @@ func (h *installer) Install(version string, parameters map[string]interface{}) e
 	if err != nil {
 		return err
 	}
+	if err := h.applyChartCRDs(chart, parameters); err != nil {
+		return errors.Wrap(err, errApplyChartCRDs)
+	}
+	if h.onlyCRDs {
+		return nil
+	}
 	_, err = h.installClient.Run(chart, parameters)
 	return err
 }
@@ -328,13 +533,32 @@ func (h *installer) Upgrade(version string, parameters map[string]interface{}) e
 	if err != nil {
 		return err
 	}
-	if h.releaseName == crossplaneChartName && !equivalentVersions(current, version) && !h.force {
-		return errors.New(errUpgradeCrossplaneVersion)
+	// Apply the version policy before pulling the chart whenever the target
+	// version is known up front so that an invalid upgrade fails fast.
+	target := strings.TrimPrefix(version, "v")
+	if target != "" {
+		if err := uxp.CheckUpgradeVersion(current, target, h.force, h.confirm); err != nil {
+			return err
+		}
 	}
 	chart, err := h.pullAndLoad(version)
 	if err != nil {
 		return err
 	}
+	if target == "" {
+		if chart.Metadata == nil {
+			return errors.New(errVerifyTargetVersion)
+		}
+		if err := uxp.CheckUpgradeVersion(current, chart.Metadata.Version, h.force, h.confirm); err != nil {
+			return err
+		}
+	}
+	if err := h.applyChartCRDs(chart, parameters); err != nil {
+		return errors.Wrap(err, errApplyChartCRDs)
+	}
+	if h.onlyCRDs {
+		return nil
+	}
 	_, upErr := h.upgradeClient.Run(h.releaseName, chart, parameters)
 	if upErr != nil && h.rollbackOnError {
 		if rErr := h.rollbackClient.Run(h.releaseName); rErr != nil {
@@ -351,6 +575,20 @@ func (h *installer) Uninstall() error {
 	return err
 }
 
+// applyChartCRDs applies the CRDs bundled with and rendered from c before
+// install or upgrade runs, so that Helm's upgrade path, which otherwise
+// skips CRDs, does not leave them stale.
+func (h *installer) applyChartCRDs(c *chart.Chart, parameters map[string]interface{}) error {
+	if h.skipCRDs {
+		return nil
+	}
+	rel, err := h.renderClient.Run(c, parameters)
+	if err != nil {
+		return errors.Wrap(err, errRenderChartTemplates)
+	}
+	return applyCRDs(context.Background(), h.crdClient, c, rel.Manifest)
+}
+
 // pullAndLoad pulls and loads a chart or fetches it from the catch.
 func (h *installer) pullAndLoad(version string) (*chart.Chart, error) {
 	// helm strips versions with leading v, which can cause issues when fetching
@@ -405,23 +643,3 @@ func (h *installer) pullChart(version string) error {
 	_, err := h.pullClient.Run(h.chartName)
 	return err
 }
-
-// equivalentVersions determines if two versions are equivalent by comparing
-// their major, minor, and patch versions. This is used to determine if a
-// crossplane version can be upgraded to the specified universal-crossplane
-// version, which should only have what this semver package considers as
-// different prerelease data.
-func equivalentVersions(current, target string) bool {
-	curV, err := semver.NewVersion(current)
-	if err != nil {
-		return false
-	}
-	tarV, err := semver.NewVersion(target)
-	if err != nil {
-		return false
-	}
-	if curV.Major() == tarV.Major() && curV.Minor() == tarV.Minor() && curV.Patch() == tarV.Patch() {
-		return true
-	}
-	return false
-}
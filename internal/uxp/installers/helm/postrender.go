@@ -0,0 +1,97 @@
+// Copyright 2021 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/pkg/errors"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	errParseRenderedManifest = "could not parse rendered manifest"
+	errMarshalPatchedObject  = "could not marshal patched object"
+)
+
+// labelAnnotationPostRenderer is a postrender.PostRenderer that merges a set
+// of labels and annotations into every object in a rendered manifest,
+// including the pod template of workload kinds, so that all resources
+// installed by up can be selected, audited, or garbage collected.
+type labelAnnotationPostRenderer struct {
+	labels      map[string]string
+	annotations map[string]string
+}
+
+// Run implements postrender.PostRenderer.
+func (p *labelAnnotationPostRenderer) Run(renderedManifests *bytes.Buffer) (*bytes.Buffer, error) {
+	out := &bytes.Buffer{}
+	decoder := k8syaml.NewYAMLOrJSONDecoder(renderedManifests, 4096)
+	for {
+		obj := map[string]interface{}{}
+		if err := decoder.Decode(&obj); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, errors.Wrap(err, errParseRenderedManifest)
+		}
+		if len(obj) == 0 {
+			continue
+		}
+		p.stampMetadata(obj)
+		if spec, ok := obj["spec"].(map[string]interface{}); ok {
+			if tmpl, ok := spec["template"].(map[string]interface{}); ok {
+				p.stampMetadata(tmpl)
+			}
+		}
+		b, err := yaml.Marshal(obj)
+		if err != nil {
+			return nil, errors.Wrap(err, errMarshalPatchedObject)
+		}
+		out.WriteString("---\n")
+		out.Write(b)
+	}
+	return out, nil
+}
+
+// stampMetadata merges p's labels and annotations into obj's
+// metadata.labels and metadata.annotations, creating either map as needed.
+func (p *labelAnnotationPostRenderer) stampMetadata(obj map[string]interface{}) {
+	metadata, ok := obj["metadata"].(map[string]interface{})
+	if !ok {
+		metadata = map[string]interface{}{}
+		obj["metadata"] = metadata
+	}
+	mergeStringMap(metadata, "labels", p.labels)
+	mergeStringMap(metadata, "annotations", p.annotations)
+}
+
+// mergeStringMap merges values into the map stored at key within metadata,
+// creating it if necessary.
+func mergeStringMap(metadata map[string]interface{}, key string, values map[string]string) {
+	if len(values) == 0 {
+		return
+	}
+	existing, ok := metadata[key].(map[string]interface{})
+	if !ok {
+		existing = map[string]interface{}{}
+	}
+	for k, v := range values {
+		existing[k] = v
+	}
+	metadata[key] = existing
+}
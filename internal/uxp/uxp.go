@@ -0,0 +1,50 @@
+// Copyright 2021 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uxp
+
+import (
+	"k8s.io/client-go/rest"
+)
+
+// Installer defines the API contract for installing, upgrading, and
+// uninstalling UXP in a cluster.
+type Installer interface {
+	GetCurrentVersion() (string, error)
+	GetReleaseInfo() (*ReleaseInfo, error)
+	Install(version string, parameters map[string]interface{}) error
+	Upgrade(version string, parameters map[string]interface{}) error
+	Uninstall() error
+}
+
+// ReleaseInfo describes the currently installed UXP Helm release.
+type ReleaseInfo struct {
+	Name      string `json:"name"`
+	Chart     string `json:"chart"`
+	Version   string `json:"version"`
+	Namespace string `json:"namespace"`
+	Revision  int    `json:"revision"`
+}
+
+// ParameterParser parses parameters supplied for installing or upgrading
+// UXP.
+type ParameterParser interface {
+	Parse() (map[string]interface{}, error)
+}
+
+// Context includes common data that UXP commands may utilize.
+type Context struct {
+	Kubeconfig *rest.Config
+	Namespace  string
+}
@@ -0,0 +1,73 @@
+// Copyright 2021 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uxp
+
+import (
+	"fmt"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/pkg/errors"
+)
+
+// ErrConfirmationMissing indicates that an upgrade crosses a minor version
+// boundary and must be explicitly confirmed before it will be performed.
+var ErrConfirmationMissing = errors.New("upgrade crosses a minor version boundary and requires confirmation")
+
+// InvalidUpgradeError indicates that an upgrade from Current to Target is
+// not permitted by UXP's upgrade policy.
+type InvalidUpgradeError struct {
+	Current string
+	Target  string
+	Reason  string
+}
+
+// Error satisfies the error interface.
+func (e *InvalidUpgradeError) Error() string {
+	return fmt.Sprintf("cannot upgrade from %s to %s: %s", e.Current, e.Target, e.Reason)
+}
+
+// CheckUpgradeVersion applies UXP's version upgrade policy to a current and
+// target version. Versions that differ only in prerelease data are always
+// allowed, which covers the crossplane -> universal-crossplane transition.
+// Otherwise, downgrades and major version jumps are never allowed, a minor
+// version jump greater than one requires force, and any minor version jump
+// requires confirm.
+func CheckUpgradeVersion(current, target string, force, confirm bool) error {
+	curV, err := semver.NewVersion(current)
+	if err != nil {
+		return errors.Wrap(err, "could not parse current version")
+	}
+	tarV, err := semver.NewVersion(target)
+	if err != nil {
+		return errors.Wrap(err, "could not parse target version")
+	}
+	if curV.Major() == tarV.Major() && curV.Minor() == tarV.Minor() && curV.Patch() == tarV.Patch() {
+		return nil
+	}
+	if tarV.LessThan(curV) {
+		return &InvalidUpgradeError{Current: current, Target: target, Reason: "downgrades are not allowed"}
+	}
+	if tarV.Major() != curV.Major() {
+		return &InvalidUpgradeError{Current: current, Target: target, Reason: "major version upgrades are not allowed"}
+	}
+	minorJump := tarV.Minor() - curV.Minor()
+	if minorJump > 1 && !force {
+		return &InvalidUpgradeError{Current: current, Target: target, Reason: "minor version upgrades of more than one version require --force"}
+	}
+	if minorJump >= 1 && !confirm {
+		return ErrConfirmationMissing
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+// Copyright 2021 Upbound Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uxp
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckUpgradeVersion(t *testing.T) {
+	type args struct {
+		current string
+		target  string
+		force   bool
+		confirm bool
+	}
+	cases := map[string]struct {
+		args    args
+		wantErr error
+		wantInv bool
+	}{
+		"EquivalentPrereleaseOnly": {
+			// The crossplane -> universal-crossplane transition changes
+			// only prerelease metadata and should never require confirm.
+			args: args{current: "1.14.0-rc.1", target: "1.14.0"},
+		},
+		"SamePatch": {
+			args: args{current: "1.14.0", target: "1.14.0"},
+		},
+		"Downgrade": {
+			args:    args{current: "1.14.0", target: "1.13.0"},
+			wantInv: true,
+		},
+		"MajorJump": {
+			args:    args{current: "1.14.0", target: "2.0.0"},
+			wantInv: true,
+		},
+		"MinorJumpOneNoConfirm": {
+			args:    args{current: "1.14.0", target: "1.15.0"},
+			wantErr: ErrConfirmationMissing,
+		},
+		"MinorJumpOneConfirmed": {
+			args: args{current: "1.14.0", target: "1.15.0", confirm: true},
+		},
+		"MinorJumpManyNoForce": {
+			args:    args{current: "1.14.0", target: "1.17.0"},
+			wantInv: true,
+		},
+		"MinorJumpManyForcedNoConfirm": {
+			args:    args{current: "1.14.0", target: "1.17.0", force: true},
+			wantErr: ErrConfirmationMissing,
+		},
+		"MinorJumpManyForcedConfirmed": {
+			args: args{current: "1.14.0", target: "1.17.0", force: true, confirm: true},
+		},
+		"PatchJumpNoConfirmRequired": {
+			args: args{current: "1.14.0", target: "1.14.3"},
+		},
+		"InvalidCurrentVersion": {
+			args:    args{current: "not-a-version", target: "1.14.0"},
+			wantInv: false,
+			wantErr: nil, // checked separately below; just want a non-nil, non-InvalidUpgradeError error
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := CheckUpgradeVersion(tc.args.current, tc.args.target, tc.args.force, tc.args.confirm)
+
+			if name == "InvalidCurrentVersion" {
+				if err == nil {
+					t.Fatalf("CheckUpgradeVersion(...): expected a parse error, got nil")
+				}
+				var invalid *InvalidUpgradeError
+				if errors.As(err, &invalid) {
+					t.Fatalf("CheckUpgradeVersion(...): expected a parse error, got InvalidUpgradeError: %v", err)
+				}
+				return
+			}
+
+			if tc.wantErr != nil {
+				if !errors.Is(err, tc.wantErr) {
+					t.Fatalf("CheckUpgradeVersion(...): want error %v, got %v", tc.wantErr, err)
+				}
+				return
+			}
+
+			var invalid *InvalidUpgradeError
+			if tc.wantInv {
+				if !errors.As(err, &invalid) {
+					t.Fatalf("CheckUpgradeVersion(...): want *InvalidUpgradeError, got %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("CheckUpgradeVersion(...): want no error, got %v", err)
+			}
+		})
+	}
+}